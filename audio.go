@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// TranscribeRequest is the backend-agnostic input to a speech-to-text call.
+type TranscribeRequest struct {
+	Audio    io.Reader
+	Filename string
+	Language string
+	Prompt   string
+}
+
+// TranscribeResponse is a completed transcription.
+type TranscribeResponse struct {
+	Text string
+}
+
+// SpeechRequest is the backend-agnostic input to a text-to-speech call.
+type SpeechRequest struct {
+	Text   string
+	Voice  string
+	Format string
+}
+
+// AudioBackend abstracts over the speech-to-text and text-to-speech
+// provider, mirroring LLMBackend so a local Whisper/TTS binding could be
+// swapped in later without touching the handlers.
+type AudioBackend interface {
+	Transcribe(ctx context.Context, req TranscribeRequest) (TranscribeResponse, error)
+	SynthesizeSpeech(ctx context.Context, req SpeechRequest) (io.ReadCloser, error)
+}
+
+// OpenAIAudioBackend adapts the hosted OpenAI audio API to AudioBackend.
+type OpenAIAudioBackend struct {
+	TranscriptionModel string
+	TTSModel           openai.SpeechModel
+}
+
+func (b *OpenAIAudioBackend) Transcribe(ctx context.Context, req TranscribeRequest) (TranscribeResponse, error) {
+	if openaiClient == nil {
+		return TranscribeResponse{}, fmt.Errorf("OpenAI client not initialized")
+	}
+
+	resp, err := openaiClient.CreateTranscription(ctx, openai.AudioRequest{
+		Model:    b.TranscriptionModel,
+		Reader:   req.Audio,
+		FilePath: req.Filename,
+		Language: req.Language,
+		Prompt:   req.Prompt,
+		Format:   openai.AudioResponseFormatJSON,
+	})
+	if err != nil {
+		return TranscribeResponse{}, err
+	}
+	return TranscribeResponse{Text: resp.Text}, nil
+}
+
+func (b *OpenAIAudioBackend) SynthesizeSpeech(ctx context.Context, req SpeechRequest) (io.ReadCloser, error) {
+	if openaiClient == nil {
+		return nil, fmt.Errorf("OpenAI client not initialized")
+	}
+
+	voice := openai.SpeechVoice(req.Voice)
+	if voice == "" {
+		voice = openai.VoiceAlloy
+	}
+	format := openai.SpeechResponseFormat(req.Format)
+	if format == "" {
+		format = openai.SpeechResponseFormatMp3
+	}
+
+	return openaiClient.CreateSpeech(ctx, openai.CreateSpeechRequest{
+		Model:          b.TTSModel,
+		Input:          req.Text,
+		Voice:          voice,
+		ResponseFormat: format,
+	})
+}
+
+// AudioBackendRegistry mirrors BackendRegistry for audio backends, so a
+// self-hosted Whisper/TTS server could be registered under "local" the
+// same way LocalBackend is for text completions.
+type AudioBackendRegistry struct {
+	backends       map[string]AudioBackend
+	defaultBackend string
+}
+
+func NewAudioBackendRegistry(defaultBackend string) *AudioBackendRegistry {
+	return &AudioBackendRegistry{
+		backends:       make(map[string]AudioBackend),
+		defaultBackend: defaultBackend,
+	}
+}
+
+func (r *AudioBackendRegistry) Register(name string, backend AudioBackend) {
+	r.backends[name] = backend
+}
+
+func (r *AudioBackendRegistry) Get(name string) (AudioBackend, error) {
+	if name == "" {
+		name = r.defaultBackend
+	}
+	backend, ok := r.backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown audio backend: %s", name)
+	}
+	return backend, nil
+}
+
+var audioBackendRegistry = NewAudioBackendRegistry(envOrDefault("AUDIO_BACKEND", "openai"))
+
+func init() {
+	audioBackendRegistry.Register("openai", &OpenAIAudioBackend{
+		TranscriptionModel: openai.Whisper1,
+		TTSModel:           openai.TTSModel1,
+	})
+}
+
+// handleTranscribe accepts a multipart audio upload and returns its
+// transcript. With ?stream=true it replays the transcript word-by-word
+// over SSE, reusing the same token-streaming UX as the text endpoints.
+func handleTranscribe(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Missing audio file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	backend, err := audioBackendRegistry.Get(r.FormValue("backend"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Transcribing uploaded audio %s", header.Filename)
+
+	resp, err := backend.Transcribe(r.Context(), TranscribeRequest{
+		Audio:    file,
+		Filename: header.Filename,
+		Language: r.FormValue("language"),
+		Prompt:   r.FormValue("prompt"),
+	})
+	if err != nil {
+		log.Printf("Transcription failed: %v", err)
+		http.Error(w, fmt.Sprintf("Transcription failed: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	streamResult, _ := strconv.ParseBool(r.URL.Query().Get("stream"))
+	if !streamResult {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"text": resp.Text})
+		return
+	}
+
+	sw := newSSEWriter(w)
+	var id int64
+	for _, word := range splitIntoWords(resp.Text) {
+		id++
+		sw.write(sseEvent{ID: id, Event: "token", Data: word})
+	}
+	id++
+	sw.write(sseEvent{ID: id, Event: "done", Data: "{}"})
+}
+
+// ttsRequest is the JSON body for POST /ai/tts.
+type ttsRequest struct {
+	Text   string `json:"text"`
+	Voice  string `json:"voice"`
+	Format string `json:"format"`
+}
+
+// handleTTS synthesizes speech for the given text and streams the audio
+// bytes back as a chunked HTTP response.
+func handleTTS(w http.ResponseWriter, r *http.Request) {
+	var req ttsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		http.Error(w, "Text cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	backend, err := audioBackendRegistry.Get(r.URL.Query().Get("backend"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Synthesizing speech for %d characters of text", len(req.Text))
+
+	audio, err := backend.SynthesizeSpeech(r.Context(), SpeechRequest{
+		Text:   req.Text,
+		Voice:  req.Voice,
+		Format: req.Format,
+	})
+	if err != nil {
+		log.Printf("Speech synthesis failed: %v", err)
+		http.Error(w, fmt.Sprintf("Speech synthesis failed: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+	defer audio.Close()
+
+	w.Header().Set("Content-Type", audioContentType(req.Format))
+
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := audio.Read(buf)
+		if n > 0 {
+			w.Write(buf[:n])
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if readErr != nil {
+			if readErr != io.EOF {
+				log.Printf("Error streaming synthesized audio: %v", readErr)
+			}
+			break
+		}
+	}
+}
+
+func audioContentType(format string) string {
+	switch format {
+	case "opus":
+		return "audio/opus"
+	case "aac":
+		return "audio/aac"
+	case "flac":
+		return "audio/flac"
+	case "wav":
+		return "audio/wav"
+	case "pcm":
+		return "audio/pcm"
+	default:
+		return "audio/mpeg"
+	}
+}
@@ -0,0 +1,461 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ChatRequest is the backend-agnostic representation of a chat completion
+// request, translated to each LLMBackend's own wire format.
+type ChatRequest struct {
+	Messages []openai.ChatCompletionMessage
+	Tools    []openai.Tool
+}
+
+// ChunkType identifies what a streamed Chunk carries.
+type ChunkType string
+
+const (
+	ChunkTypeContent  ChunkType = "content"
+	ChunkTypeToolCall ChunkType = "tool_call"
+)
+
+// Chunk is one piece of a streamed completion. Err is set, and is the last
+// value sent, if the backend failed partway through the stream.
+type Chunk struct {
+	Type      ChunkType
+	Content   string
+	ToolCalls []openai.ToolCall
+	Err       error
+}
+
+// Response is a complete, non-streamed completion.
+type Response struct {
+	Content   string
+	RateLimit RateLimitHeaders
+	Usage     Usage
+}
+
+// LLMBackend abstracts over the model provider used to answer a question,
+// so the HTTP handlers don't need to know whether they're talking to
+// hosted OpenAI, a self-hosted model, or Anthropic. Stream also returns the
+// rate-limit headers from the initial response, since those only arrive
+// once, before any Chunk does.
+type LLMBackend interface {
+	Stream(ctx context.Context, req ChatRequest) (<-chan Chunk, RateLimitHeaders, error)
+	Complete(ctx context.Context, req ChatRequest) (Response, error)
+}
+
+// OpenAIBackend adapts the hosted OpenAI API to the LLMBackend interface.
+type OpenAIBackend struct {
+	Model string
+}
+
+func (b *OpenAIBackend) Stream(ctx context.Context, req ChatRequest) (<-chan Chunk, RateLimitHeaders, error) {
+	if openaiClient == nil {
+		return nil, RateLimitHeaders{}, fmt.Errorf("OpenAI client not initialized")
+	}
+
+	stream, err := openaiClient.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
+		Model:    b.Model,
+		Messages: req.Messages,
+		Tools:    req.Tools,
+		Stream:   true,
+	})
+	if err != nil {
+		return nil, RateLimitHeaders{}, err
+	}
+
+	return streamChunks(stream), parseRateLimitHeaders(stream.Header()), nil
+}
+
+func (b *OpenAIBackend) Complete(ctx context.Context, req ChatRequest) (Response, error) {
+	if openaiClient == nil {
+		return Response{}, fmt.Errorf("OpenAI client not initialized")
+	}
+
+	resp, err := openaiClient.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:    b.Model,
+		Messages: req.Messages,
+		Tools:    req.Tools,
+	})
+	if err != nil {
+		return Response{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return Response{}, fmt.Errorf("no choices returned")
+	}
+	return Response{
+		Content:   resp.Choices[0].Message.Content,
+		RateLimit: parseRateLimitHeaders(resp.Header()),
+		Usage: Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+// LocalBackend talks to a self-hosted, OpenAI-compatible completion server,
+// such as llama.cpp's server mode or a LocalAI instance, reached over its
+// own base URL.
+type LocalBackend struct {
+	client *openai.Client
+	model  string
+}
+
+// NewLocalBackend builds a LocalBackend pointed at an OpenAI-compatible
+// endpoint. Most local servers don't check the API key, so any non-empty
+// value works.
+func NewLocalBackend(baseURL, model string) *LocalBackend {
+	cfg := openai.DefaultConfig("local")
+	cfg.BaseURL = baseURL
+	return &LocalBackend{client: openai.NewClientWithConfig(cfg), model: model}
+}
+
+func (b *LocalBackend) Stream(ctx context.Context, req ChatRequest) (<-chan Chunk, RateLimitHeaders, error) {
+	stream, err := b.client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
+		Model:    b.model,
+		Messages: req.Messages,
+		Tools:    req.Tools,
+		Stream:   true,
+	})
+	if err != nil {
+		return nil, RateLimitHeaders{}, err
+	}
+
+	// Most local servers don't send rate-limit headers at all; parsing an
+	// empty header set just yields a zero RateLimitHeaders.
+	return streamChunks(stream), parseRateLimitHeaders(stream.Header()), nil
+}
+
+func (b *LocalBackend) Complete(ctx context.Context, req ChatRequest) (Response, error) {
+	resp, err := b.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:    b.model,
+		Messages: req.Messages,
+		Tools:    req.Tools,
+	})
+	if err != nil {
+		return Response{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return Response{}, fmt.Errorf("no choices returned")
+	}
+	return Response{
+		Content:   resp.Choices[0].Message.Content,
+		RateLimit: parseRateLimitHeaders(resp.Header()),
+		Usage: Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+// anthropicMaxTokens bounds how long a single Anthropic completion may run.
+// The Messages API requires max_tokens on every request, unlike OpenAI's
+// chat completions where it's optional.
+const anthropicMaxTokens = 1024
+
+// AnthropicBackend adapts Anthropic's Messages API to the LLMBackend
+// interface. There's no official Go SDK vendored in this repo, so it talks
+// to the HTTP API directly rather than pulling in a second client
+// dependency alongside go-openai.
+//
+// Tool calls aren't translated: a request that carries any tools fails
+// with an explicit error rather than being answered without them. The SSE
+// and WebSocket handlers always send the registered tools, so only
+// tool-free requests (such as /ai/nostream and /ai/test) are answered by
+// this backend. OpenAI's tool-call wire format
+// doesn't map onto Anthropic's tool_use content blocks without its own
+// translation layer, which is out of scope for this minimal adapter.
+type AnthropicBackend struct {
+	apiKey  string
+	model   string
+	baseURL string
+	client  *http.Client
+}
+
+// NewAnthropicBackend builds an AnthropicBackend for the hosted API at
+// baseURL (override for a proxy or a mock in tests).
+func NewAnthropicBackend(apiKey, model, baseURL string) *AnthropicBackend {
+	return &AnthropicBackend{apiKey: apiKey, model: model, baseURL: baseURL, client: &http.Client{}}
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	MaxTokens int                `json:"max_tokens"`
+	Stream    bool               `json:"stream,omitempty"`
+}
+
+type anthropicContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	Content []anthropicContentBlock `json:"content"`
+	Usage   anthropicUsage          `json:"usage"`
+}
+
+// anthropicChatMessages splits an OpenAI-shaped message list into
+// Anthropic's separate top-level system prompt and user/assistant turns.
+func anthropicChatMessages(messages []openai.ChatCompletionMessage) (string, []anthropicMessage) {
+	var system string
+	turns := make([]anthropicMessage, 0, len(messages))
+	for _, m := range messages {
+		switch m.Role {
+		case openai.ChatMessageRoleSystem:
+			system = m.Content
+		case openai.ChatMessageRoleUser:
+			turns = append(turns, anthropicMessage{Role: "user", Content: m.Content})
+		case openai.ChatMessageRoleAssistant:
+			turns = append(turns, anthropicMessage{Role: "assistant", Content: m.Content})
+		}
+	}
+	return system, turns
+}
+
+func (b *AnthropicBackend) newRequest(ctx context.Context, req ChatRequest, stream bool) (*http.Request, error) {
+	if b.apiKey == "" {
+		return nil, fmt.Errorf("Anthropic client not initialized")
+	}
+	if len(req.Tools) > 0 {
+		return nil, fmt.Errorf("AnthropicBackend does not support tool calling yet")
+	}
+
+	system, turns := anthropicChatMessages(req.Messages)
+	body, err := json.Marshal(anthropicRequest{
+		Model:     b.model,
+		System:    system,
+		Messages:  turns,
+		MaxTokens: anthropicMaxTokens,
+		Stream:    stream,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, b.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", b.apiKey)
+	httpReq.Header.Set("anthropic-version", "2023-06-01")
+	return httpReq, nil
+}
+
+func (b *AnthropicBackend) Complete(ctx context.Context, req ChatRequest) (Response, error) {
+	httpReq, err := b.newRequest(ctx, req, false)
+	if err != nil {
+		return Response{}, err
+	}
+
+	resp, err := b.client.Do(httpReq)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return Response{}, fmt.Errorf("Anthropic API error (%d): %s", resp.StatusCode, data)
+	}
+
+	var parsed anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return Response{}, err
+	}
+
+	var content strings.Builder
+	for _, block := range parsed.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+
+	return Response{
+		Content:   content.String(),
+		RateLimit: parseRateLimitHeaders(resp.Header),
+		Usage: Usage{
+			PromptTokens:     parsed.Usage.InputTokens,
+			CompletionTokens: parsed.Usage.OutputTokens,
+			TotalTokens:      parsed.Usage.InputTokens + parsed.Usage.OutputTokens,
+		},
+	}, nil
+}
+
+func (b *AnthropicBackend) Stream(ctx context.Context, req ChatRequest) (<-chan Chunk, RateLimitHeaders, error) {
+	httpReq, err := b.newRequest(ctx, req, true)
+	if err != nil {
+		return nil, RateLimitHeaders{}, err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := b.client.Do(httpReq)
+	if err != nil {
+		return nil, RateLimitHeaders{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, RateLimitHeaders{}, fmt.Errorf("Anthropic API error (%d): %s", resp.StatusCode, data)
+	}
+
+	// Most rate-limit-relevant headers only arrive once, on this initial
+	// response, same as the OpenAI-compatible stream path.
+	return anthropicStreamChunks(resp.Body), parseRateLimitHeaders(resp.Header), nil
+}
+
+// anthropicStreamChunk is the subset of Anthropic's SSE event payloads this
+// adapter understands: text deltas. Other event types (message_start,
+// content_block_start/stop, message_stop, ...) carry no content and are
+// skipped.
+type anthropicStreamChunk struct {
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+}
+
+// anthropicStreamChunks drains an Anthropic Messages API SSE stream into a
+// Chunk channel, mirroring streamChunks' shape for the OpenAI-compatible
+// backends.
+func anthropicStreamChunks(body io.ReadCloser) <-chan Chunk {
+	chunks := make(chan Chunk)
+	go func() {
+		defer close(chunks)
+		defer body.Close()
+
+		scanner := bufio.NewScanner(body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			data := strings.TrimPrefix(line, "data: ")
+			if data == line {
+				continue // not a data line (event:, blank line, etc.)
+			}
+
+			var chunk anthropicStreamChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				continue // ignore events this adapter doesn't model
+			}
+			if chunk.Delta.Type == "text_delta" && chunk.Delta.Text != "" {
+				chunks <- Chunk{Type: ChunkTypeContent, Content: chunk.Delta.Text}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			chunks <- Chunk{Err: err}
+		}
+	}()
+	return chunks
+}
+
+// streamChunks drains an OpenAI-compatible chat stream into a Chunk channel,
+// shared by the OpenAI and local backends since both speak the same
+// streaming wire format.
+func streamChunks(stream *openai.ChatCompletionStream) <-chan Chunk {
+	chunks := make(chan Chunk)
+	go func() {
+		defer close(chunks)
+		defer stream.Close()
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF && err.Error() != "stream finished" {
+					chunks <- Chunk{Err: err}
+				}
+				return
+			}
+
+			if len(resp.Choices) == 0 {
+				continue
+			}
+			delta := resp.Choices[0].Delta
+
+			if len(delta.ToolCalls) > 0 {
+				chunks <- Chunk{Type: ChunkTypeToolCall, ToolCalls: delta.ToolCalls}
+			}
+			if delta.Content != "" {
+				chunks <- Chunk{Type: ChunkTypeContent, Content: delta.Content}
+			}
+		}
+	}()
+	return chunks
+}
+
+// BackendRegistry maps a backend name to its implementation, falling back
+// to a configured default when the caller doesn't name one.
+type BackendRegistry struct {
+	backends       map[string]LLMBackend
+	defaultBackend string
+}
+
+func NewBackendRegistry(defaultBackend string) *BackendRegistry {
+	return &BackendRegistry{
+		backends:       make(map[string]LLMBackend),
+		defaultBackend: defaultBackend,
+	}
+}
+
+// Register adds a backend to the registry under name.
+func (r *BackendRegistry) Register(name string, backend LLMBackend) {
+	r.backends[name] = backend
+}
+
+// Get returns the named backend, or the registry's default when name is
+// empty.
+func (r *BackendRegistry) Get(name string) (LLMBackend, error) {
+	if name == "" {
+		name = r.defaultBackend
+	}
+	backend, ok := r.backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown LLM backend: %s", name)
+	}
+	return backend, nil
+}
+
+var backendRegistry = NewBackendRegistry(envOrDefault("LLM_BACKEND", "openai"))
+
+func init() {
+	backendRegistry.Register("openai", &OpenAIBackend{Model: openai.GPT3Dot5Turbo})
+	backendRegistry.Register("local", NewLocalBackend(
+		envOrDefault("LOCAL_LLM_BASE_URL", "http://localhost:8081/v1"),
+		envOrDefault("LOCAL_LLM_MODEL", "local-model"),
+	))
+	backendRegistry.Register("anthropic", NewAnthropicBackend(
+		os.Getenv("ANTHROPIC_API_KEY"),
+		envOrDefault("ANTHROPIC_MODEL", "claude-3-5-sonnet-latest"),
+		envOrDefault("ANTHROPIC_BASE_URL", "https://api.anthropic.com"),
+	))
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
@@ -23,12 +22,17 @@ var upgrader = websocket.Upgrader{
 }
 
 type Message struct {
-	Type    string `json:"type"`
-	Content string `json:"content"`
+	Type      string `json:"type"`
+	Content   string `json:"content"`
+	SessionID string `json:"session_id,omitempty"`
+	Backend   string `json:"backend,omitempty"`
 }
 
 type UserQuestion struct {
-	Question string `json:"question"`
+	Question  string `json:"question"`
+	SessionID string `json:"session_id,omitempty"`
+	Backend   string `json:"backend,omitempty"`
+	StreamID  string `json:"stream_id,omitempty"`
 }
 
 type AIResponse struct {
@@ -68,6 +72,15 @@ func main() {
 	r.HandleFunc("/ai/nostream", handleAINoStream).Methods("POST")
 	r.HandleFunc("/ai/test", handleAITest)
 
+	// Session endpoints for multi-turn conversations
+	r.HandleFunc("/ai/session", handleCreateSession).Methods("POST")
+	r.HandleFunc("/ai/session/{id}", handleDeleteSession).Methods("DELETE")
+	r.HandleFunc("/ai/session/{id}/history", handleGetSessionHistory).Methods("GET")
+
+	// Audio endpoints for voice input/output
+	r.HandleFunc("/ai/transcribe", handleTranscribe).Methods("POST")
+	r.HandleFunc("/ai/tts", handleTTS).Methods("POST")
+
 	fmt.Println("Server starting on http://localhost:8080")
 	fmt.Println("AI Agent Demo: Available at http://localhost:8080/ai")
 	fmt.Println("AI Test Endpoint: Available at http://localhost:8080/ai/test")
@@ -82,16 +95,32 @@ func serveAI(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "static/ai.html")
 }
 
-// Demo SSE handler (original functionality)
+// Demo SSE handler (original functionality). Supports resume: a client
+// that reconnects with ?stream_id=... and a Last-Event-ID header picks up
+// the same stream instead of restarting it.
 func handleSSE(w http.ResponseWriter, r *http.Request) {
-	// Set headers for SSE
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if streamID := r.URL.Query().Get("stream_id"); streamID != "" {
+		stream, ok := sseStreams.lookup(streamID)
+		sw := newSSEWriter(w)
+		if !ok {
+			sw.write(sseEvent{Event: "error", Data: `{"message":"unknown stream_id"}`})
+			return
+		}
+		sw.write(sseEvent{Event: "stream_id", Data: streamID})
+		replaySSEStream(r, sw, stream)
+		return
+	}
+
+	streamID := generateStreamID()
+	stream, _ := sseStreams.getOrCreate(streamID)
+	sw := newSSEWriter(w)
+	sw.write(sseEvent{Event: "stream_id", Data: streamID})
+	defer stream.close()
 
-	// Get context from request
 	ctx := r.Context()
+	emit := func(event, data string) {
+		sw.write(stream.append(event, data))
+	}
 
 	// Simulate streaming text generation
 	text := "Hello! This is a demonstration of real-time text streaming from Go backend to JavaScript frontend. The text is being generated word by word and sent to the frontend as it becomes available. This creates a much better user experience compared to waiting for the entire response to be generated before displaying anything."
@@ -103,9 +132,7 @@ func handleSSE(w http.ResponseWriter, r *http.Request) {
 			// Client disconnected
 			return
 		default:
-			// Send the word as an SSE event
-			fmt.Fprintf(w, "data: %s\n\n", word)
-			w.(http.Flusher).Flush()
+			emit("token", word)
 
 			// Simulate processing time
 			time.Sleep(200 * time.Millisecond)
@@ -113,8 +140,45 @@ func handleSSE(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Send end signal
-	fmt.Fprintf(w, "data: [END]\n\n")
-	w.(http.Flusher).Flush()
+	emit("done", "{}")
+}
+
+// replaySSEStream replays whatever the client missed, then, if the stream
+// is still being produced elsewhere, tails it live until it completes or
+// the client disconnects.
+func replaySSEStream(r *http.Request, sw *sseWriter, stream *sseStream) {
+	// Subscribe before replaying so no event appended in between is lost;
+	// live events the replay already covered are skipped below.
+	ch := stream.subscribe()
+	if ch != nil {
+		defer stream.unsubscribe(ch)
+	}
+
+	lastSent := lastEventID(r)
+	missed, stillActive := stream.replay(lastSent)
+	for _, ev := range missed {
+		sw.write(ev)
+		lastSent = ev.ID
+	}
+	if !stillActive || ch == nil {
+		return
+	}
+
+	ctx := r.Context()
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			if ev.ID <= lastSent {
+				continue
+			}
+			sw.write(ev)
+		case <-ctx.Done():
+			return
+		}
+	}
 }
 
 // Demo WebSocket handler (original functionality)
@@ -156,11 +220,6 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 // AI Agent SSE handler
 func handleAISSE(w http.ResponseWriter, r *http.Request) {
-	if openaiClient == nil {
-		http.Error(w, "OpenAI client not initialized", http.StatusServiceUnavailable)
-		return
-	}
-
 	// Parse user question
 	var userQuestion UserQuestion
 	if err := json.NewDecoder(r.Body).Decode(&userQuestion); err != nil {
@@ -169,100 +228,142 @@ func handleAISSE(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A request carrying a stream_id is a reconnect: resume the stream it
+	// names instead of asking the LLM backend a new question.
+	if userQuestion.StreamID != "" {
+		stream, ok := sseStreams.lookup(userQuestion.StreamID)
+		sw := newSSEWriter(w)
+		if !ok {
+			sw.write(sseEvent{Event: "error", Data: `{"message":"unknown stream_id"}`})
+			return
+		}
+		replaySSEStream(r, sw, stream)
+		return
+	}
+
 	if strings.TrimSpace(userQuestion.Question) == "" {
 		http.Error(w, "Question cannot be empty", http.StatusBadRequest)
 		return
 	}
 
+	backend, err := backendRegistry.Get(userQuestion.Backend)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	log.Printf("Received question via SSE: %s", userQuestion.Question)
 
 	// Start timing
 	startTime := time.Now()
 
-	// Set headers for SSE
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	streamID := generateStreamID()
+	stream, _ := sseStreams.getOrCreate(streamID)
+	defer stream.close()
 
-	ctx := r.Context()
-
-	// Create OpenAI request
-	req := openai.ChatCompletionRequest{
-		Model: openai.GPT3Dot5Turbo,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: userQuestion.Question,
-			},
-		},
-		Stream: true,
+	sw := newSSEWriter(w)
+	sw.write(sseEvent{Event: "stream_id", Data: streamID})
+	emit := func(event, data string) {
+		sw.write(stream.append(event, data))
 	}
 
-	log.Printf("Creating OpenAI stream request...")
-
-	// Create streaming response
-	stream, err := openaiClient.CreateChatCompletionStream(ctx, req)
-	if err != nil {
-		log.Printf("OpenAI stream creation failed: %v", err)
-		fmt.Fprintf(w, "data: [ERROR] Failed to connect to OpenAI: %s\n\n", err.Error())
-		w.(http.Flusher).Flush()
-		return
-	}
-	defer stream.Close()
+	ctx := r.Context()
 
-	log.Printf("OpenAI stream created successfully, starting to receive data...")
+	// Reuse prior session history if present
+	messages := buildChatMessages(userQuestion.SessionID, userQuestion.Question)
 
-	// Stream the response
+	// Stream the response, looping to let the model call tools and resume
+	// streaming with the results before producing its final answer.
 	hasReceivedContent := false
 	firstContentTime := time.Time{}
-	for {
-		response, err := stream.Recv()
+	var answer strings.Builder
+
+	for round := 0; round < maxToolRounds; round++ {
+		log.Printf("Creating LLM stream request (round %d)...", round+1)
+
+		chunks, rateLimit, err := backend.Stream(ctx, ChatRequest{Messages: messages, Tools: toolRegistry.OpenAITools()})
 		if err != nil {
-			if err == io.EOF {
-				log.Printf("OpenAI stream ended normally (EOF)")
-				break
-			}
-			if err.Error() == "stream finished" {
-				log.Printf("OpenAI stream finished normally")
-				break
-			}
-			log.Printf("Stream receive error: %v (type: %T)", err, err)
-			if !hasReceivedContent {
-				fmt.Fprintf(w, "data: [ERROR] OpenAI API error: %s\n\n", err.Error())
-				w.(http.Flusher).Flush()
-			}
+			log.Printf("LLM stream creation failed: %v", err)
+			emit("error", fmt.Sprintf(`{"message":%q}`, err.Error()))
 			return
 		}
 
-		if len(response.Choices) > 0 {
-			content := response.Choices[0].Delta.Content
-			if content != "" {
+		if round == 0 {
+			emit("ratelimit", rateLimitEventJSON(rateLimit))
+		}
+
+		log.Printf("LLM stream created successfully, starting to receive data...")
+
+		var roundContent strings.Builder
+		toolCalls := newToolCallAccumulator()
+
+		for chunk := range chunks {
+			if chunk.Err != nil {
+				log.Printf("Stream receive error: %v", chunk.Err)
+				if !hasReceivedContent {
+					emit("error", fmt.Sprintf(`{"message":%q}`, chunk.Err.Error()))
+				}
+				return
+			}
+
+			if chunk.Type == ChunkTypeToolCall {
+				toolCalls.add(chunk.ToolCalls)
+			}
+
+			if chunk.Type == ChunkTypeContent && chunk.Content != "" {
 				hasReceivedContent = true
+				roundContent.WriteString(chunk.Content)
+				answer.WriteString(chunk.Content)
 
 				// Track first content received
 				if firstContentTime.IsZero() {
 					firstContentTime = time.Now()
 					responseTime := firstContentTime.Sub(startTime).Seconds()
 					log.Printf("First content received in %.2f seconds", responseTime)
-					fmt.Fprintf(w, "data: [RESPONSE_TIME] %.2f\n\n", responseTime)
-					w.(http.Flusher).Flush()
+					emit("timing", fmt.Sprintf(`{"response_time":%.2f}`, responseTime))
 				}
 
 				// Send each word as it comes
-				words := splitIntoWords(content)
+				words := splitIntoWords(chunk.Content)
 				for _, word := range words {
 					select {
 					case <-ctx.Done():
 						log.Printf("Client disconnected, stopping stream")
 						return
 					default:
-						fmt.Fprintf(w, "data: %s\n\n", word)
-						w.(http.Flusher).Flush()
+						emit("token", word)
 					}
 				}
 			}
 		}
+
+		calls := toolCalls.complete()
+		if len(calls) == 0 {
+			break
+		}
+
+		// The assistant asked for one or more tool calls; run them and feed
+		// the results back for a follow-up completion.
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:      openai.ChatMessageRoleAssistant,
+			Content:   roundContent.String(),
+			ToolCalls: calls,
+		})
+
+		for _, call := range calls {
+			log.Printf("Invoking tool %s with args %s", call.Function.Name, call.Function.Arguments)
+			emit("tool_call", toolCallEventJSON(call.Function.Name, call.Function.Arguments))
+
+			result, callErr := toolRegistry.Call(call.Function.Name, json.RawMessage(call.Function.Arguments))
+			resultJSON := toolResultEventJSON(result, callErr)
+			emit("tool_result", resultJSON)
+
+			messages = append(messages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				ToolCallID: call.ID,
+				Content:    resultJSON,
+			})
+		}
 	}
 
 	// Calculate duration
@@ -270,29 +371,24 @@ func handleAISSE(w http.ResponseWriter, r *http.Request) {
 	durationSeconds := duration.Seconds()
 
 	if !hasReceivedContent {
-		log.Printf("No content received from OpenAI, sending error message")
-		fmt.Fprintf(w, "data: [ERROR] No response received from OpenAI. Please check your API key and account status.\n\n")
-		w.(http.Flusher).Flush()
+		log.Printf("No content received from LLM backend, sending error message")
+		emit("error", `{"message":"No response received from the LLM backend. Please check your API key and account status."}`)
 	} else {
 		log.Printf("Stream completed successfully in %.2f seconds", durationSeconds)
+		recordTurn(userQuestion.SessionID, userQuestion.Question, answer.String())
 	}
 
-	// Send timing information
-	fmt.Fprintf(w, "data: [TIMING] %.2f\n\n", durationSeconds)
-	w.(http.Flusher).Flush()
+	// Send timing information, with an approximate token count since a
+	// streamed response carries no exact usage from the backend
+	usage := approxStreamUsage(messages, answer.String())
+	emit("timing", fmt.Sprintf(`{"total_time":%.2f,"usage":%s}`, durationSeconds, usageJSON(usage)))
 
 	// Send end signal
-	fmt.Fprintf(w, "data: [END]\n\n")
-	w.(http.Flusher).Flush()
+	emit("done", "{}")
 }
 
 // AI Agent WebSocket handler
 func handleAIWebSocket(w http.ResponseWriter, r *http.Request) {
-	if openaiClient == nil {
-		http.Error(w, "OpenAI client not initialized", http.StatusServiceUnavailable)
-		return
-	}
-
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade failed: %v", err)
@@ -320,58 +416,62 @@ func handleAIWebSocket(w http.ResponseWriter, r *http.Request) {
 
 			log.Printf("Received question via WebSocket: %s", question)
 
-			// Start timing
-			startTime := time.Now()
-
-			// Create OpenAI request
-			req := openai.ChatCompletionRequest{
-				Model: openai.GPT3Dot5Turbo,
-				Messages: []openai.ChatCompletionMessage{
-					{
-						Role:    openai.ChatMessageRoleUser,
-						Content: question,
-					},
-				},
-				Stream: true,
-			}
-
-			log.Printf("Creating OpenAI stream request for WebSocket...")
-
-			// Create streaming response
-			stream, err := openaiClient.CreateChatCompletionStream(context.Background(), req)
+			backend, err := backendRegistry.Get(message.Backend)
 			if err != nil {
-				log.Printf("OpenAI stream creation failed for WebSocket: %v", err)
-				conn.WriteJSON(AIResponse{Type: "error", Error: fmt.Sprintf("Failed to connect to OpenAI: %s", err.Error())})
+				conn.WriteJSON(AIResponse{Type: "error", Error: err.Error()})
 				continue
 			}
 
-			log.Printf("OpenAI stream created successfully for WebSocket, starting to receive data...")
+			// Start timing
+			startTime := time.Now()
 
-			// Stream the response
+			// Reuse prior session history if present
+			messages := buildChatMessages(message.SessionID, question)
+
+			// Stream the response, looping to let the model call tools and
+			// resume streaming with the results before its final answer.
 			hasReceivedContent := false
 			firstContentTime := time.Time{}
-			for {
-				response, err := stream.Recv()
+			var answer strings.Builder
+
+			for round := 0; round < maxToolRounds; round++ {
+				log.Printf("Creating LLM stream request for WebSocket (round %d)...", round+1)
+
+				chunks, rateLimit, err := backend.Stream(context.Background(), ChatRequest{Messages: messages, Tools: toolRegistry.OpenAITools()})
 				if err != nil {
-					if err == io.EOF {
-						log.Printf("OpenAI stream ended normally (EOF) for WebSocket")
-						break
-					}
-					if err.Error() == "stream finished" {
-						log.Printf("OpenAI stream finished normally for WebSocket")
-						break
-					}
-					log.Printf("Stream receive error for WebSocket: %v (type: %T)", err, err)
-					if !hasReceivedContent {
-						conn.WriteJSON(AIResponse{Type: "error", Error: fmt.Sprintf("OpenAI API error: %s", err.Error())})
-					}
+					log.Printf("LLM stream creation failed for WebSocket: %v", err)
+					conn.WriteJSON(AIResponse{Type: "error", Error: fmt.Sprintf("Failed to connect to LLM backend: %s", err.Error())})
 					break
 				}
 
-				if len(response.Choices) > 0 {
-					content := response.Choices[0].Delta.Content
-					if content != "" {
+				if round == 0 {
+					conn.WriteJSON(AIResponse{Type: "ratelimit", Content: rateLimitEventJSON(rateLimit)})
+				}
+
+				log.Printf("LLM stream created successfully for WebSocket, starting to receive data...")
+
+				var roundContent strings.Builder
+				toolCalls := newToolCallAccumulator()
+				disconnected := false
+
+			chunkLoop:
+				for chunk := range chunks {
+					if chunk.Err != nil {
+						log.Printf("Stream receive error for WebSocket: %v", chunk.Err)
+						if !hasReceivedContent {
+							conn.WriteJSON(AIResponse{Type: "error", Error: fmt.Sprintf("LLM backend error: %s", chunk.Err.Error())})
+						}
+						break chunkLoop
+					}
+
+					if chunk.Type == ChunkTypeToolCall {
+						toolCalls.add(chunk.ToolCalls)
+					}
+
+					if chunk.Type == ChunkTypeContent && chunk.Content != "" {
 						hasReceivedContent = true
+						roundContent.WriteString(chunk.Content)
+						answer.WriteString(chunk.Content)
 
 						// Track first content received
 						if firstContentTime.IsZero() {
@@ -382,33 +482,66 @@ func handleAIWebSocket(w http.ResponseWriter, r *http.Request) {
 						}
 
 						// Send each word as it comes
-						words := splitIntoWords(content)
+						words := splitIntoWords(chunk.Content)
 						for _, word := range words {
-							err := conn.WriteJSON(AIResponse{Type: "word", Content: word})
-							if err != nil {
+							if err := conn.WriteJSON(AIResponse{Type: "word", Content: word}); err != nil {
 								log.Printf("WebSocket write error: %v", err)
-								return
+								disconnected = true
+								break chunkLoop
 							}
 						}
 					}
 				}
-			}
 
-			stream.Close()
+				if disconnected {
+					return
+				}
+
+				calls := toolCalls.complete()
+				if len(calls) == 0 {
+					break
+				}
+
+				// The assistant asked for one or more tool calls; run them
+				// and feed the results back for a follow-up completion.
+				messages = append(messages, openai.ChatCompletionMessage{
+					Role:      openai.ChatMessageRoleAssistant,
+					Content:   roundContent.String(),
+					ToolCalls: calls,
+				})
+
+				for _, call := range calls {
+					log.Printf("Invoking tool %s with args %s via WebSocket", call.Function.Name, call.Function.Arguments)
+					conn.WriteJSON(AIResponse{Type: "tool_call", Content: toolCallEventJSON(call.Function.Name, call.Function.Arguments)})
+
+					result, callErr := toolRegistry.Call(call.Function.Name, json.RawMessage(call.Function.Arguments))
+					resultJSON := toolResultEventJSON(result, callErr)
+					conn.WriteJSON(AIResponse{Type: "tool_result", Content: resultJSON})
+
+					messages = append(messages, openai.ChatCompletionMessage{
+						Role:       openai.ChatMessageRoleTool,
+						ToolCallID: call.ID,
+						Content:    resultJSON,
+					})
+				}
+			}
 
 			// Calculate duration
 			duration := time.Since(startTime)
 			durationSeconds := duration.Seconds()
 
 			if !hasReceivedContent {
-				log.Printf("No content received from OpenAI for WebSocket, sending error message")
-				conn.WriteJSON(AIResponse{Type: "error", Error: "No response received from OpenAI. Please check your API key and account status."})
+				log.Printf("No content received from LLM backend for WebSocket, sending error message")
+				conn.WriteJSON(AIResponse{Type: "error", Error: "No response received from the LLM backend. Please check your API key and account status."})
 			} else {
 				log.Printf("WebSocket stream completed successfully in %.2f seconds", durationSeconds)
+				recordTurn(message.SessionID, question, answer.String())
 			}
 
-			// Send timing information
-			conn.WriteJSON(AIResponse{Type: "timing", Content: fmt.Sprintf("%.2f", durationSeconds)})
+			// Send timing information, with an approximate token count
+			// since a streamed response carries no exact usage
+			usage := approxStreamUsage(messages, answer.String())
+			conn.WriteJSON(AIResponse{Type: "timing", Content: fmt.Sprintf(`{"total_time":%.2f,"usage":%s}`, durationSeconds, usageJSON(usage))})
 
 			// Send end signal
 			conn.WriteJSON(AIResponse{Type: "end"})
@@ -416,6 +549,52 @@ func handleAIWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// buildChatMessages returns the messages to send to the LLM backend for a question,
+// reusing the session's prior history when a sessionID is supplied. When no
+// session is given it falls back to sending just the question, preserving
+// the original single-turn behavior.
+func buildChatMessages(sessionID, question string) []openai.ChatCompletionMessage {
+	if sessionID == "" {
+		return []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: question,
+			},
+		}
+	}
+
+	history, ok := sessionStore.GetHistory(sessionID)
+	if !ok {
+		history = nil
+	}
+
+	messages := make([]openai.ChatCompletionMessage, len(history), len(history)+1)
+	copy(messages, history)
+	messages = append(messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: question,
+	})
+	return messages
+}
+
+// recordTurn appends the user question and assistant answer to the session
+// history, if a session was used.
+func recordTurn(sessionID, question, answer string) {
+	if sessionID == "" {
+		return
+	}
+	sessionStore.AppendMessages(sessionID,
+		openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: question,
+		},
+		openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: answer,
+		},
+	)
+}
+
 func splitIntoWords(text string) []string {
 	// Simple word splitting for demo
 	var words []string
@@ -441,55 +620,48 @@ func splitIntoWords(text string) []string {
 	return words
 }
 
-// AI Test handler to verify OpenAI API connectivity
+// AI Test handler to verify LLM backend connectivity
 func handleAITest(w http.ResponseWriter, r *http.Request) {
-	if openaiClient == nil {
-		http.Error(w, "OpenAI client not initialized", http.StatusServiceUnavailable)
+	backendName := r.URL.Query().Get("backend")
+	backend, err := backendRegistry.Get(backendName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("Testing OpenAI API connectivity...")
+	log.Printf("Testing LLM backend connectivity...")
 
 	// Test with a simple request
-	req := openai.ChatCompletionRequest{
-		Model: openai.GPT3Dot5Turbo,
+	resp, err := backend.Complete(context.Background(), ChatRequest{
 		Messages: []openai.ChatCompletionMessage{
 			{
 				Role:    openai.ChatMessageRoleUser,
 				Content: "Hello",
 			},
 		},
-		MaxTokens: 10,
-	}
-
-	resp, err := openaiClient.CreateChatCompletion(context.Background(), req)
+	})
 	if err != nil {
-		log.Printf("OpenAI API test failed: %v", err)
+		log.Printf("LLM backend test failed: %v", err)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{
 			"status":  "error",
-			"message": fmt.Sprintf("OpenAI API test failed: %v", err),
+			"message": fmt.Sprintf("LLM backend test failed: %v", err),
 		})
 		return
 	}
 
-	log.Printf("OpenAI API test successful")
+	log.Printf("LLM backend test successful")
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"status":   "success",
-		"message":  "OpenAI API is working correctly",
-		"response": resp.Choices[0].Message.Content,
+		"message":  "LLM backend is working correctly",
+		"response": resp.Content,
 	})
 }
 
 // AI No-Stream handler for complete response at once
 func handleAINoStream(w http.ResponseWriter, r *http.Request) {
-	if openaiClient == nil {
-		http.Error(w, "OpenAI client not initialized", http.StatusServiceUnavailable)
-		return
-	}
-
 	// Parse user question
 	var userQuestion UserQuestion
 	if err := json.NewDecoder(r.Body).Decode(&userQuestion); err != nil {
@@ -503,34 +675,30 @@ func handleAINoStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	backend, err := backendRegistry.Get(userQuestion.Backend)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	log.Printf("Received question via No-Stream: %s", userQuestion.Question)
 
 	// Start timing - from question receipt to complete response
 	startTime := time.Now()
 	log.Printf("Starting No-Stream processing timer...")
 
-	// Create OpenAI request (non-streaming)
-	req := openai.ChatCompletionRequest{
-		Model: openai.GPT3Dot5Turbo,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: userQuestion.Question,
-			},
-		},
-		Stream: false, // Non-streaming request
-	}
-
-	log.Printf("Creating OpenAI non-streaming request...")
+	log.Printf("Creating non-streaming LLM request...")
 
-	// Get complete response
-	resp, err := openaiClient.CreateChatCompletion(context.Background(), req)
+	// Get complete response, reusing prior session history if present
+	resp, err := backend.Complete(context.Background(), ChatRequest{
+		Messages: buildChatMessages(userQuestion.SessionID, userQuestion.Question),
+	})
 	if err != nil {
-		log.Printf("OpenAI non-streaming request failed: %v", err)
+		log.Printf("LLM non-streaming request failed: %v", err)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Failed to get response from OpenAI: %s", err.Error()),
+			"error": fmt.Sprintf("Failed to get response from LLM backend: %s", err.Error()),
 		})
 		return
 	}
@@ -541,16 +709,22 @@ func handleAINoStream(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("No-Stream processing completed:")
 	log.Printf("  - Question received and processed")
-	log.Printf("  - OpenAI API call completed")
+	log.Printf("  - LLM backend call completed")
 	log.Printf("  - Complete response generated")
 	log.Printf("  - Total time: %.2f seconds", durationSeconds)
 
+	recordTurn(userQuestion.SessionID, userQuestion.Question, resp.Content)
+
+	// Forward the upstream rate-limit headers to our own caller
+	resp.RateLimit.applyTo(w.Header())
+
 	// Prepare response
 	response := map[string]interface{}{
-		"response":      resp.Choices[0].Message.Content,
+		"response":      resp.Content,
 		"timing":        durationSeconds,
 		"response_time": durationSeconds, // For No-Stream, response time equals total processing time
 		"status":        "success",
+		"usage":         resp.Usage,
 	}
 
 	// Send response
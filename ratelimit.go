@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// RateLimitHeaders mirrors the x-ratelimit-* headers an OpenAI-compatible
+// server returns on every response, so a caller can see how close it is to
+// getting throttled without inspecting raw HTTP headers itself.
+type RateLimitHeaders struct {
+	LimitRequests     string `json:"limit_requests,omitempty"`
+	RemainingRequests string `json:"remaining_requests,omitempty"`
+	ResetRequests     string `json:"reset_requests,omitempty"`
+	LimitTokens       string `json:"limit_tokens,omitempty"`
+	RemainingTokens   string `json:"remaining_tokens,omitempty"`
+	ResetTokens       string `json:"reset_tokens,omitempty"`
+}
+
+// IsZero reports whether none of the rate-limit headers were present, e.g.
+// because the backend doesn't send them.
+func (h RateLimitHeaders) IsZero() bool {
+	return h == RateLimitHeaders{}
+}
+
+// parseRateLimitHeaders extracts OpenAI's rate-limit headers from a raw
+// http.Header.
+func parseRateLimitHeaders(h http.Header) RateLimitHeaders {
+	return RateLimitHeaders{
+		LimitRequests:     h.Get("x-ratelimit-limit-requests"),
+		RemainingRequests: h.Get("x-ratelimit-remaining-requests"),
+		ResetRequests:     h.Get("x-ratelimit-reset-requests"),
+		LimitTokens:       h.Get("x-ratelimit-limit-tokens"),
+		RemainingTokens:   h.Get("x-ratelimit-remaining-tokens"),
+		ResetTokens:       h.Get("x-ratelimit-reset-tokens"),
+	}
+}
+
+// applyTo copies the non-empty rate-limit fields onto an outgoing
+// http.Header, so a non-streaming response can forward what it saw from
+// upstream to its own caller.
+func (h RateLimitHeaders) applyTo(out http.Header) {
+	set := func(name, value string) {
+		if value != "" {
+			out.Set(name, value)
+		}
+	}
+	set("x-ratelimit-limit-requests", h.LimitRequests)
+	set("x-ratelimit-remaining-requests", h.RemainingRequests)
+	set("x-ratelimit-reset-requests", h.ResetRequests)
+	set("x-ratelimit-limit-tokens", h.LimitTokens)
+	set("x-ratelimit-remaining-tokens", h.RemainingTokens)
+	set("x-ratelimit-reset-tokens", h.ResetTokens)
+}
+
+// Usage is the token accounting for a completion.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// approxTokenCount roughly estimates token count by counting whitespace-
+// separated words. It's used on the streaming path, where the backend
+// doesn't report exact usage, as a stand-in for a real tokenizer.
+func approxTokenCount(text string) int {
+	return len(strings.Fields(text))
+}
+
+// approxStreamUsage estimates Usage for a streamed answer, since streaming
+// responses don't come back with an exact token count the way a
+// non-streaming completion does.
+func approxStreamUsage(messages []openai.ChatCompletionMessage, answer string) Usage {
+	prompt := 0
+	for _, m := range messages {
+		prompt += approxTokenCount(m.Content)
+	}
+	completion := approxTokenCount(answer)
+	return Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      prompt + completion,
+	}
+}
+
+// rateLimitEventJSON and usageJSON render their arguments for embedding in
+// an SSE data payload.
+func rateLimitEventJSON(h RateLimitHeaders) string {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+func usageJSON(u Usage) string {
+	data, err := json.Marshal(u)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
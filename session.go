@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultMaxTurns     = 20
+	defaultSystemPrompt = "You are a helpful assistant."
+)
+
+// Session holds the message history for a single multi-turn conversation.
+type Session struct {
+	ID           string                         `json:"id"`
+	SystemPrompt string                         `json:"system_prompt"`
+	Messages     []openai.ChatCompletionMessage `json:"-"`
+	MaxTurns     int                            `json:"max_turns"`
+	CreatedAt    time.Time                      `json:"created_at"`
+	UpdatedAt    time.Time                      `json:"updated_at"`
+}
+
+// SessionStore keeps sessions in memory, keyed by session ID.
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+func NewSessionStore() *SessionStore {
+	return &SessionStore{
+		sessions: make(map[string]*Session),
+	}
+}
+
+var sessionStore = NewSessionStore()
+
+// CreateSession creates a new session with an optional system prompt and
+// max-turn trim depth, and returns it. A maxTurns of 0 falls back to
+// defaultMaxTurns.
+func (s *SessionStore) CreateSession(systemPrompt string, maxTurns int) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if systemPrompt == "" {
+		systemPrompt = defaultSystemPrompt
+	}
+	if maxTurns == 0 {
+		maxTurns = defaultMaxTurns
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:           generateSessionID(),
+		SystemPrompt: systemPrompt,
+		MaxTurns:     maxTurns,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	session.Messages = append(session.Messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: systemPrompt,
+	})
+
+	s.sessions[session.ID] = session
+	return session
+}
+
+// GetSession returns the session for id, if it exists.
+func (s *SessionStore) GetSession(id string) (*Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	return session, ok
+}
+
+// DeleteSession removes a session, reporting whether it existed.
+func (s *SessionStore) DeleteSession(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[id]; !ok {
+		return false
+	}
+	delete(s.sessions, id)
+	return true
+}
+
+// AppendMessages adds one or more messages to the session history under a
+// single lock and trims once afterwards. A user/assistant turn must be
+// appended together through one AppendMessages call rather than two separate
+// calls: trimming after only the user half would drop just the user message
+// of the oldest turn, leaving the history opening with a dangling assistant
+// message (no preceding user turn) visible to any concurrent reader until
+// the paired append lands.
+func (s *SessionStore) AppendMessages(id string, messages ...openai.ChatCompletionMessage) (*Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	session.Messages = append(session.Messages, messages...)
+	session.UpdatedAt = time.Now()
+	trimSessionHistory(session)
+	return session, true
+}
+
+// GetHistory returns a copy of the session's message history.
+func (s *SessionStore) GetHistory(id string) ([]openai.ChatCompletionMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	history := make([]openai.ChatCompletionMessage, len(session.Messages))
+	copy(history, session.Messages)
+	return history, true
+}
+
+// trimSessionHistory keeps the leading system prompt plus the most recent
+// MaxTurns user/assistant turns, dropping the oldest turns once the session
+// grows past that limit.
+func trimSessionHistory(session *Session) {
+	maxTurns := session.MaxTurns
+	if maxTurns <= 0 {
+		maxTurns = defaultMaxTurns
+	}
+	maxMessages := maxTurns*2 + 1 // +1 for the system prompt
+	if len(session.Messages) <= maxMessages {
+		return
+	}
+
+	overflow := len(session.Messages) - maxMessages
+	trimmed := make([]openai.ChatCompletionMessage, 0, maxMessages)
+	trimmed = append(trimmed, session.Messages[0])
+	trimmed = append(trimmed, session.Messages[overflow+1:]...)
+	session.Messages = trimmed
+}
+
+func generateSessionID() string {
+	return fmt.Sprintf("sess_%d", time.Now().UnixNano())
+}
+
+// createSessionRequest is the optional body for POST /ai/session.
+type createSessionRequest struct {
+	SystemPrompt string `json:"system_prompt"`
+	MaxTurns     int    `json:"max_turns"`
+}
+
+// handleCreateSession creates a new conversation session and returns its ID.
+func handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	var req createSessionRequest
+	if r.Body != nil {
+		// The body is optional; ignore decode errors from an empty body.
+		json.NewDecoder(r.Body).Decode(&req)
+	}
+
+	if req.MaxTurns < 0 {
+		http.Error(w, "max_turns must be positive", http.StatusBadRequest)
+		return
+	}
+
+	session := sessionStore.CreateSession(req.SystemPrompt, req.MaxTurns)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// handleDeleteSession removes a conversation session.
+func handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if !sessionStore.DeleteSession(id) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetSessionHistory returns the message history for a session.
+func handleGetSessionHistory(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	history, ok := sessionStore.GetHistory(id)
+	if !ok {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":      id,
+		"history": history,
+	})
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	sseRetryMillis    = 3000
+	sseBufferCapacity = 500
+)
+
+// sseEvent is one frame of an SSE stream: a named event with its payload
+// and a monotonically increasing ID a client can echo back via
+// Last-Event-ID to resume after a dropped connection.
+type sseEvent struct {
+	ID    int64
+	Event string
+	Data  string
+}
+
+// sseStream is the single source of truth for one question's events. The
+// connection that first creates a stream is its producer: it appends
+// events as they're generated, which both retains them in a capped ring
+// buffer for replay and fans them out live to any other connection
+// currently reading the same stream. A client that reconnects with
+// Last-Event-ID subscribes to the same stream instead of asking the LLM
+// backend again.
+type sseStream struct {
+	mu          sync.Mutex
+	id          string
+	registry    *sseStreamRegistry
+	nextID      int64
+	events      []sseEvent
+	subscribers map[chan sseEvent]struct{}
+	closed      bool
+}
+
+func newSSEStream(id string, registry *sseStreamRegistry) *sseStream {
+	return &sseStream{id: id, registry: registry, subscribers: make(map[chan sseEvent]struct{})}
+}
+
+// append records an event and delivers it to any live subscribers.
+func (s *sseStream) append(event, data string) sseEvent {
+	s.mu.Lock()
+	s.nextID++
+	ev := sseEvent{ID: s.nextID, Event: event, Data: data}
+	s.events = append(s.events, ev)
+	if len(s.events) > sseBufferCapacity {
+		s.events = s.events[len(s.events)-sseBufferCapacity:]
+	}
+	subs := make([]chan sseEvent, 0, len(s.subscribers))
+	for ch := range s.subscribers {
+		subs = append(subs, ch)
+	}
+	s.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- ev:
+		default:
+			// Subscriber's buffer is full or it's no longer being read
+			// (e.g. its consumer goroutine already returned on ctx.Done()).
+			// Drop the event rather than block the producer indefinitely.
+		}
+	}
+	return ev
+}
+
+// close marks the stream finished, disconnects any live subscribers, and
+// evicts it from the registry so a finished stream doesn't sit in memory
+// for the lifetime of the process.
+func (s *sseStream) close() {
+	s.mu.Lock()
+	s.closed = true
+	for ch := range s.subscribers {
+		close(ch)
+	}
+	s.subscribers = make(map[chan sseEvent]struct{})
+	s.mu.Unlock()
+
+	if s.registry != nil {
+		s.registry.remove(s.id)
+	}
+}
+
+// replay returns buffered events after lastEventID, and whether the stream
+// is still being produced (so the caller knows whether to also subscribe
+// for live events afterwards).
+func (s *sseStream) replay(lastEventID int64) ([]sseEvent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var missed []sseEvent
+	for _, ev := range s.events {
+		if ev.ID > lastEventID {
+			missed = append(missed, ev)
+		}
+	}
+	return missed, !s.closed
+}
+
+// subscribe registers a channel that receives events as append() delivers
+// them, for as long as the stream stays open. Returns nil if the stream
+// already finished.
+func (s *sseStream) subscribe() chan sseEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	ch := make(chan sseEvent, 16)
+	s.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (s *sseStream) unsubscribe(ch chan sseEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subscribers, ch)
+}
+
+// sseStreamRegistry hands out the sseStream for a given stream ID, so a
+// reconnecting request finds the same one its original producer is
+// writing to.
+type sseStreamRegistry struct {
+	mu      sync.Mutex
+	streams map[string]*sseStream
+}
+
+func newSSEStreamRegistry() *sseStreamRegistry {
+	return &sseStreamRegistry{streams: make(map[string]*sseStream)}
+}
+
+// getOrCreate returns the stream for id, and whether it already existed
+// (i.e. this caller is reconnecting to a stream someone else is producing).
+func (r *sseStreamRegistry) getOrCreate(id string) (*sseStream, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stream, ok := r.streams[id]; ok {
+		return stream, true
+	}
+	stream := newSSEStream(id, r)
+	r.streams[id] = stream
+	return stream, false
+}
+
+// lookup returns the stream already registered under id, without creating
+// one. Reconnect paths must use this instead of getOrCreate: a client that
+// supplies a made-up stream_id must not be able to materialize a
+// permanently-open, never-produced-to stream in the registry.
+func (r *sseStreamRegistry) lookup(id string) (*sseStream, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stream, ok := r.streams[id]
+	return stream, ok
+}
+
+// remove evicts id from the registry. Called by sseStream.close() once a
+// stream finishes so the map doesn't grow unbounded over the process
+// lifetime.
+func (r *sseStreamRegistry) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.streams, id)
+}
+
+var sseStreams = newSSEStreamRegistry()
+
+func generateStreamID() string {
+	return fmt.Sprintf("strm_%d", time.Now().UnixNano())
+}
+
+// sseWriter frames events onto an http.ResponseWriter as id/event/data
+// lines per the SSE spec, sending a retry hint as soon as the connection
+// opens.
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newSSEWriter(w http.ResponseWriter) *sseWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	sw := &sseWriter{w: w, flusher: w.(http.Flusher)}
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetryMillis)
+	sw.flusher.Flush()
+	return sw
+}
+
+func (sw *sseWriter) write(ev sseEvent) {
+	fmt.Fprintf(sw.w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Event, ev.Data)
+	sw.flusher.Flush()
+}
+
+// lastEventID reads the reconnect hint a browser's EventSource sends
+// automatically, or that a non-browser client can set manually to resume a
+// dropped stream.
+func lastEventID(r *http.Request) int64 {
+	header := r.Header.Get("Last-Event-ID")
+	if header == "" {
+		header = r.URL.Query().Get("last_event_id")
+	}
+	id, _ := strconv.ParseInt(header, 10, 64)
+	return id
+}
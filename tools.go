@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ToolHandler executes a registered tool given its raw JSON arguments and
+// returns a JSON-serializable result.
+type ToolHandler func(args json.RawMessage) (interface{}, error)
+
+// Tool pairs an OpenAI function definition with the Go handler that
+// implements it.
+type Tool struct {
+	Definition openai.FunctionDefinition
+	Handler    ToolHandler
+}
+
+// ToolRegistry holds the set of tools the AI agent is allowed to call.
+type ToolRegistry struct {
+	tools map[string]Tool
+}
+
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{tools: make(map[string]Tool)}
+}
+
+// Register adds a tool to the registry, keyed by its function name.
+func (r *ToolRegistry) Register(tool Tool) {
+	r.tools[tool.Definition.Name] = tool
+}
+
+// OpenAITools returns the registered tools in the format expected by
+// ChatCompletionRequest.Tools. Returns nil when no tools are registered, so
+// callers can pass it straight through without an extra empty-slice check.
+func (r *ToolRegistry) OpenAITools() []openai.Tool {
+	if len(r.tools) == 0 {
+		return nil
+	}
+	tools := make([]openai.Tool, 0, len(r.tools))
+	for _, tool := range r.tools {
+		def := tool.Definition
+		tools = append(tools, openai.Tool{
+			Type:     openai.ToolTypeFunction,
+			Function: &def,
+		})
+	}
+	return tools
+}
+
+// Call invokes the named tool with the given raw JSON arguments.
+func (r *ToolRegistry) Call(name string, args json.RawMessage) (interface{}, error) {
+	tool, ok := r.tools[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tool: %s", name)
+	}
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	return tool.Handler(args)
+}
+
+// maxToolRounds bounds how many times a single question may bounce between
+// the model and the tool handlers before we give up and return whatever
+// answer we have.
+const maxToolRounds = 5
+
+var toolRegistry = NewToolRegistry()
+
+func init() {
+	toolRegistry.Register(Tool{
+		Definition: openai.FunctionDefinition{
+			Name:        "get_current_time",
+			Description: "Get the current date and time on the server, in RFC3339 format.",
+			Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		Handler: func(args json.RawMessage) (interface{}, error) {
+			return map[string]string{"time": time.Now().Format(time.RFC3339)}, nil
+		},
+	})
+}
+
+// toolCallAccumulator collects streamed tool-call deltas, which arrive by
+// index with the name and argument text split across many chunks, into
+// complete tool calls once the stream for a round ends.
+type toolCallAccumulator struct {
+	byIndex map[int]*openai.ToolCall
+	order   []int
+}
+
+func newToolCallAccumulator() *toolCallAccumulator {
+	return &toolCallAccumulator{byIndex: make(map[int]*openai.ToolCall)}
+}
+
+func (a *toolCallAccumulator) add(deltas []openai.ToolCall) {
+	for _, delta := range deltas {
+		idx := 0
+		if delta.Index != nil {
+			idx = *delta.Index
+		}
+
+		call, ok := a.byIndex[idx]
+		if !ok {
+			call = &openai.ToolCall{Index: delta.Index}
+			a.byIndex[idx] = call
+			a.order = append(a.order, idx)
+		}
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		if delta.Type != "" {
+			call.Type = delta.Type
+		}
+		call.Function.Name += delta.Function.Name
+		call.Function.Arguments += delta.Function.Arguments
+	}
+}
+
+// complete returns the accumulated tool calls in the order they first
+// appeared in the stream.
+func (a *toolCallAccumulator) complete() []openai.ToolCall {
+	calls := make([]openai.ToolCall, 0, len(a.order))
+	for _, idx := range a.order {
+		calls = append(calls, *a.byIndex[idx])
+	}
+	return calls
+}
+
+// toolCallEventJSON renders the tool_call event payload for a single tool
+// invocation.
+func toolCallEventJSON(name, arguments string) string {
+	if arguments == "" {
+		arguments = "{}"
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"name":      name,
+		"arguments": json.RawMessage(arguments),
+	})
+	if err != nil {
+		data, _ = json.Marshal(map[string]string{"name": name})
+	}
+	return string(data)
+}
+
+// toolResultEventJSON renders the tool_result event payload for the outcome
+// of a tool invocation.
+func toolResultEventJSON(result interface{}, err error) string {
+	var payload map[string]interface{}
+	if err != nil {
+		payload = map[string]interface{}{"error": err.Error()}
+	} else {
+		payload = map[string]interface{}{"result": result}
+	}
+	data, marshalErr := json.Marshal(payload)
+	if marshalErr != nil {
+		return fmt.Sprintf(`{"error":%q}`, marshalErr.Error())
+	}
+	return string(data)
+}